@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheTTL is how long a cached response is served without hitting the network.
+// GitHub's GraphQL endpoint answers POSTs with no ETag/Last-Modified validators,
+// so conditional revalidation never applies here; a plain age-based TTL is what
+// actually saves quota and latency.
+const cacheTTL = 10 * time.Minute
+
+// cachingTransport is an http.RoundTripper that caches whole responses on disk,
+// keyed by a hash of the request method, URL and body, and serves the stored
+// body directly while it's within cacheTTL.
+type cachingTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// newCachingTransport returns a cachingTransport that stores responses under dir,
+// wrapping next (or http.DefaultTransport if next is nil).
+func newCachingTransport(dir string, next http.RoundTripper) *cachingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cachingTransport{dir: dir, next: next}
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := requestCacheKey(req)
+	if err != nil {
+		return t.next.RoundTrip(req)
+	}
+	path := filepath.Join(t.dir, key)
+
+	if cached, age := t.readCached(path, req); cached != nil && age < cacheTTL {
+		return cached, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		if dump, err := httputil.DumpResponse(resp, true); err == nil {
+			if err := os.MkdirAll(t.dir, 0o755); err == nil {
+				_ = os.WriteFile(path, dump, 0o644)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// readCached returns the response for req stored at path and its age, or nil
+// if there is no usable cache entry.
+func (t *cachingTransport) readCached(path string, req *http.Request) (*http.Response, time.Duration) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, 0
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, 0
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil, 0
+	}
+	return resp, time.Since(info.ModTime())
+}
+
+// requestCacheKey computes the cache key for req, restoring req.Body after reading it.
+func requestCacheKey(req *http.Request) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(req.Method))
+	h.Write([]byte(req.URL.String()))
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return "", err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
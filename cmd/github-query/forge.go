@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Repo is a forge-neutral summary of a repository.
+type Repo struct {
+	Name        string
+	Description string
+	IsArchived  bool
+	IsPrivate   bool
+	CreatedAt   time.Time
+	PushedAt    time.Time
+}
+
+// Commit is a forge-neutral summary of a commit.
+type Commit struct {
+	Oid             string
+	CommittedDate   time.Time
+	MessageHeadline string
+	AuthorEmail     string
+}
+
+// Approval is an approving review of a pull request.
+type Approval struct {
+	Author      string
+	SubmittedAt time.Time
+}
+
+// PullRequest is a forge-neutral summary of a merged pull request.
+type PullRequest struct {
+	Number              int
+	MergedAt            time.Time
+	HeadRefName         string
+	Title               string
+	Author              string
+	MergeCommitOid      string
+	MergeCommitHeadline string
+	Approvals           []Approval
+}
+
+// Issue is a forge-neutral summary of an issue filed or closed within a date range.
+type Issue struct {
+	Number    int
+	Title     string
+	Author    string
+	Labels    []string
+	Assignees []string
+	ClosedAt  time.Time
+}
+
+// Forge is implemented by each supported code-hosting backend.
+type Forge interface {
+	// ListRepos returns the repos belonging to the given organization.
+	ListRepos(org string) ([]Repo, error)
+	// ListCommits calls fn for each commit to the default branch of org/repo within
+	// [since, until), as pages are fetched. It stops and returns fn's error if fn
+	// returns a non-nil error.
+	ListCommits(org string, repo string, since time.Time, until time.Time, fn func(Commit) error) error
+	// ListPullRequests calls fn for each pull request merged to org/repo within
+	// [since, until), as pages are fetched. It stops and returns fn's error if fn
+	// returns a non-nil error.
+	ListPullRequests(org string, repo string, since time.Time, until time.Time, fn func(PullRequest) error) error
+	// ListIssues calls fn for each issue filed or closed in org/repo within
+	// [since, until), as pages are fetched. It stops and returns fn's error if fn
+	// returns a non-nil error.
+	ListIssues(org string, repo string, since time.Time, until time.Time, fn func(Issue) error) error
+}
+
+// forgeOptions carries the settings shared across Forge constructors that aren't
+// credentials.
+type forgeOptions struct {
+	// cacheDir, if non-empty, caches GitHub API responses on disk under that directory.
+	cacheDir string
+	// rateLimitThreshold is the minimum GitHub GraphQL quota to keep in reserve
+	// between paginated requests; <= 0 selects defaultRateLimitThreshold.
+	rateLimitThreshold int
+}
+
+// newForge constructs the Forge named by forgeName, reading its credentials from
+// the environment. forgeName must be one of "github", "gitlab" or "gerrit".
+func newForge(forgeName string, opts forgeOptions) (Forge, error) {
+	switch forgeName {
+	case "", "github":
+		token, ok := os.LookupEnv("GITHUB_TOKEN")
+		if !ok {
+			return nil, errors.New("Missing environment variable: GITHUB_TOKEN")
+		}
+		return NewGithubForge(token, opts.cacheDir, opts.rateLimitThreshold), nil
+	case "gitlab":
+		token, ok := os.LookupEnv("GITLAB_TOKEN")
+		if !ok {
+			return nil, errors.New("Missing environment variable: GITLAB_TOKEN")
+		}
+		return NewGitlabForge(os.Getenv("GITLAB_URL"), token), nil
+	case "gerrit":
+		user, ok := os.LookupEnv("GERRIT_USER")
+		if !ok {
+			return nil, errors.New("Missing environment variable: GERRIT_USER")
+		}
+		pass, ok := os.LookupEnv("GERRIT_PASS")
+		if !ok {
+			return nil, errors.New("Missing environment variable: GERRIT_PASS")
+		}
+		baseURL, ok := os.LookupEnv("GERRIT_URL")
+		if !ok {
+			return nil, errors.New("Missing environment variable: GERRIT_URL")
+		}
+		return NewGerritForge(baseURL, user, pass), nil
+	default:
+		return nil, fmt.Errorf("Invalid forge option: %s", forgeName)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Gerrit prefixes every REST response with this magic string to defend against XSSI.
+const gerritXSSIPrefix = ")]}'"
+
+// Gerrit's fixed-precision timestamp format, e.g. "2021-06-02 15:04:05.000000000"
+const gerritTimeLayout = "2006-01-02 15:04:05.000000000"
+
+// gerritTimestamp decodes Gerrit's non-standard timestamp format.
+type gerritTimestamp struct {
+	time.Time
+}
+
+func (t *gerritTimestamp) UnmarshalJSON(data []byte) error {
+	parsed, err := time.Parse(`"`+gerritTimeLayout+`"`, string(data))
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// GerritForge implements Forge using Gerrit's REST API.
+type GerritForge struct {
+	baseURL  string
+	user     string
+	password string
+	client   *http.Client
+}
+
+// NewGerritForge returns a GerritForge authenticated with the given HTTP credentials.
+func NewGerritForge(baseURL string, user string, password string) *GerritForge {
+	return &GerritForge{baseURL: strings.TrimSuffix(baseURL, "/"), user: user, password: password, client: http.DefaultClient}
+}
+
+// Issues a GET request against the Gerrit REST API, strips the XSSI prefix, and
+// decodes the remaining JSON response into v
+func (f *GerritForge) get(path string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, f.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(f.user, f.password)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gerrit: %s: %s", path, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	body = bytes.TrimPrefix(body, []byte(gerritXSSIPrefix))
+	return json.Unmarshal(body, v)
+}
+
+type gerritProjectInfo struct {
+	State string `json:"state"`
+}
+
+// ListRepos returns the projects hosted by this Gerrit instance. Gerrit has no concept
+// of organizations, so org is ignored.
+func (f *GerritForge) ListRepos(org string) ([]Repo, error) {
+	var projects map[string]gerritProjectInfo
+	if err := f.get("/a/projects/?d", &projects); err != nil {
+		return nil, err
+	}
+	var repos []Repo
+	for name, p := range projects {
+		repos = append(repos, Repo{
+			Name:       name,
+			IsArchived: p.State == "READ_ONLY" || p.State == "HIDDEN",
+		})
+	}
+	return repos, nil
+}
+
+// ListCommits is not supported: Gerrit's REST API has no endpoint for listing commits
+// to a branch within a date range independent of a code review change.
+func (f *GerritForge) ListCommits(org string, repo string, since time.Time, until time.Time, fn func(Commit) error) error {
+	return errors.New("gerrit: ListCommits is not supported")
+}
+
+type gerritChangeInfo struct {
+	Number  int    `json:"_number"`
+	Project string `json:"project"`
+	Branch  string `json:"branch"`
+	Subject string `json:"subject"`
+	Owner   struct {
+		Username string `json:"username"`
+	} `json:"owner"`
+	Submitted       gerritTimestamp `json:"submitted"`
+	CurrentRevision string          `json:"current_revision"`
+	MoreChanges     bool            `json:"_more_changes"`
+}
+
+// gerritPageSize is the number of changes requested per page. Gerrit caps
+// unpaginated /changes/ queries at roughly 500 results, so large projects
+// must be paged through with S (skip) and n (limit).
+const gerritPageSize = 100
+
+// ListPullRequests calls fn for each change that was merged to org/repo within the given time interval.
+//
+// The query only bounds the lower edge server-side with after:, since a
+// change's submit event always advances its last-updated time, so after:since
+// cannot exclude a change submitted at or after since. There is no equivalent
+// upper-side operator: before:until matches last-updated, not submitted, and
+// would wrongly exclude a change merged inside [since, until) but commented on
+// or relabeled afterward. The submit-time re-check below is what actually
+// enforces the [since, until) window.
+func (f *GerritForge) ListPullRequests(org string, repo string, since time.Time, until time.Time, fn func(PullRequest) error) error {
+	query := fmt.Sprintf("project:%s status:merged after:%s",
+		repo, since.Format("2006-01-02"))
+	for start := 0; ; start += gerritPageSize {
+		path := fmt.Sprintf("/a/changes/?q=%s&o=CURRENT_REVISION&n=%d&S=%d",
+			url.QueryEscape(query), gerritPageSize, start)
+		var changes []gerritChangeInfo
+		if err := f.get(path, &changes); err != nil {
+			return err
+		}
+		for _, c := range changes {
+			// Enforce the documented [since, until) window against the
+			// submit time itself; see the function doc comment for why
+			// the server-side query can't do this for us.
+			if c.Submitted.Time.Before(since) || !c.Submitted.Time.Before(until) {
+				continue
+			}
+			pr := PullRequest{
+				Number:         c.Number,
+				MergedAt:       c.Submitted.Time,
+				HeadRefName:    c.Branch,
+				Title:          c.Subject,
+				Author:         c.Owner.Username,
+				MergeCommitOid: c.CurrentRevision,
+			}
+			if err := fn(pr); err != nil {
+				return err
+			}
+		}
+		if len(changes) == 0 || !changes[len(changes)-1].MoreChanges {
+			break
+		}
+	}
+	return nil
+}
+
+// ListIssues is not supported: Gerrit is a code review tool and has no issue tracker.
+func (f *GerritForge) ListIssues(org string, repo string, since time.Time, until time.Time, fn func(Issue) error) error {
+	return errors.New("gerrit: ListIssues is not supported")
+}
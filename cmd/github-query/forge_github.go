@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/oauth2"
+)
+
+// GithubForge implements Forge using the GitHub GraphQL v4 API.
+type GithubForge struct {
+	client             *githubv4.Client
+	rateLimitThreshold int
+}
+
+// NewGithubForge returns a GithubForge authenticated with the given personal access token.
+// If cacheDir is non-empty, responses are cached on disk under that directory.
+// rateLimitThreshold is the minimum quota to keep in reserve between paginated
+// requests; <= 0 selects defaultRateLimitThreshold.
+func NewGithubForge(token string, cacheDir string, rateLimitThreshold int) *GithubForge {
+	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	httpClient := oauth2.NewClient(context.Background(), tokenSource)
+	httpClient.Transport = newRateLimitTransport(httpClient.Transport)
+	if cacheDir != "" {
+		httpClient.Transport = newCachingTransport(cacheDir, httpClient.Transport)
+	}
+	client := githubv4.NewClient(httpClient)
+	return &GithubForge{client: client, rateLimitThreshold: rateLimitThreshold}
+}
+
+// ListRepos returns the repos belonging to the specified organization.
+func (f *GithubForge) ListRepos(org string) ([]Repo, error) {
+	return organizationRepositories(*f.client, org, f.rateLimitThreshold)
+}
+
+// ListCommits calls fn for each commit within given time interval to default branch of specified repo.
+func (f *GithubForge) ListCommits(org string, repo string, since time.Time, until time.Time, fn func(Commit) error) error {
+	return repositoryCommits(*f.client, org, repo, since, until, f.rateLimitThreshold, func(c CommitData) error {
+		return fn(Commit{
+			Oid:             c.AbbreviatedOid,
+			CommittedDate:   c.CommittedDate,
+			MessageHeadline: c.MessageHeadline,
+			AuthorEmail:     c.Author.Email,
+		})
+	})
+}
+
+// ListPullRequests calls fn for each pull request that was merged to specified repo within the given time interval.
+func (f *GithubForge) ListPullRequests(org string, repo string, since time.Time, until time.Time, fn func(PullRequest) error) error {
+	return repositoryPullRequests(*f.client, org, repo, since, until, f.rateLimitThreshold, func(n PullRequestNode) error {
+		pr := PullRequest{
+			Number:              n.Number,
+			MergedAt:            n.MergedAt,
+			HeadRefName:         n.HeadRefName,
+			Title:               n.Title,
+			Author:              n.Author.Login,
+			MergeCommitOid:      n.MergeCommit.AbbreviatedOid,
+			MergeCommitHeadline: n.MergeCommit.MessageHeadline,
+		}
+		for _, r := range n.Reviews.Nodes {
+			pr.Approvals = append(pr.Approvals, Approval{Author: r.Author.Login, SubmittedAt: r.SubmittedAt})
+		}
+		return fn(pr)
+	})
+}
+
+// ListIssues calls fn for each issue filed or closed in specified repo within the given time interval.
+func (f *GithubForge) ListIssues(org string, repo string, since time.Time, until time.Time, fn func(Issue) error) error {
+	return repositoryIssues(*f.client, org, repo, since, until, f.rateLimitThreshold, func(n IssueNode) error {
+		issue := Issue{
+			Number: n.Number,
+			Title:  n.Title,
+			Author: n.Author.Login,
+		}
+		for _, l := range n.Labels.Nodes {
+			issue.Labels = append(issue.Labels, l.Name)
+		}
+		for _, a := range n.Assignees.Nodes {
+			issue.Assignees = append(issue.Assignees, a.Login)
+		}
+		if n.ClosedAt != nil {
+			issue.ClosedAt = *n.ClosedAt
+		}
+		return fn(issue)
+	})
+}
+
+type repositoryNode struct {
+	Name        string
+	Description string
+	IsArchived  bool
+	IsPrivate   bool
+	CreatedAt   time.Time
+	PushedAt    time.Time
+}
+
+type repositoryNodes []repositoryNode
+
+// Converts a repositoryNodes array into forge-neutral Repo structs
+func (nodes repositoryNodes) Repos() (repos []Repo) {
+	for _, node := range nodes {
+		repos = append(repos, Repo{
+			Name:        node.Name,
+			Description: node.Description,
+			IsArchived:  node.IsArchived,
+			IsPrivate:   node.IsPrivate,
+			CreatedAt:   node.CreatedAt,
+			PushedAt:    node.PushedAt,
+		})
+	}
+	return
+}
+
+// Returns the repos belonging to the specified organization
+func organizationRepositories(client githubv4.Client, orgName string, rateLimitThreshold int) ([]Repo, error) {
+	var query struct {
+		Organization struct {
+			Repositories struct {
+				TotalCount int
+				PageInfo   struct {
+					EndCursor   string
+					HasNextPage bool
+				}
+				Nodes repositoryNodes
+			} `graphql:"repositories(first: 100, after: $after, orderBy: {field: NAME, direction: ASC})"`
+		} `graphql:"organization(login: $login)"`
+		RateLimit rateLimitInfo
+	}
+
+	variables := map[string]interface{}{
+		"login": githubv4.String(orgName),
+		"after": (*githubv4.String)(nil), // first cursor is null
+	}
+
+	// Handle pagination
+	var repos []Repo
+	for {
+		if err := client.Query(context.Background(), &query, variables); err != nil {
+			return nil, err
+		}
+		repos = append(repos, query.Organization.Repositories.Nodes.Repos()...)
+		pageInfo := query.Organization.Repositories.PageInfo
+		if !pageInfo.HasNextPage {
+			break
+		}
+		throttleIfLow(query.RateLimit, rateLimitThreshold)
+		variables["after"] = githubv4.String(pageInfo.EndCursor)
+	}
+	return repos, nil
+}
+
+// A merged pull request, including the merge commit and approving reviews
+type PullRequestNode struct {
+	Number      int
+	MergedAt    time.Time
+	HeadRefName string
+	Title       string
+	Author      struct {
+		Login string
+	}
+	MergeCommit struct {
+		MessageHeadline string
+		AbbreviatedOid  string
+	}
+	Reviews struct {
+		Nodes []struct {
+			SubmittedAt time.Time
+			Author      struct {
+				Login string
+			}
+		}
+	} `graphql:"reviews(first: 100, states: APPROVED)"`
+}
+
+type PullRequestNodes []PullRequestNode
+
+// Calls fn for each pull request within specified date range from a PullRequestNodes array
+func (nodes PullRequestNodes) InRange(since time.Time, until time.Time, fn func(PullRequestNode) error) error {
+	for _, node := range nodes {
+		if !node.MergedAt.Before(since) && node.MergedAt.Before(until) {
+			if err := fn(node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Calls fn for each annotated pull request that was merged to specified repo within the given time interval
+func repositoryPullRequests(client githubv4.Client, orgName string, repoName string, since time.Time, until time.Time, rateLimitThreshold int, fn func(PullRequestNode) error) error {
+	var query struct {
+		Repository struct {
+			Name         string
+			PullRequests struct {
+				TotalCount int
+				PageInfo   struct {
+					EndCursor   string
+					HasNextPage bool
+				}
+				Nodes PullRequestNodes
+			} `graphql:"pullRequests(first: 100, after: $after)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit rateLimitInfo
+	}
+
+	variables := map[string]interface{}{
+		"owner": githubv4.String(orgName),
+		"name":  githubv4.String(repoName),
+		"after": (*githubv4.String)(nil), // first cursor is null
+	}
+
+	// Handle pagination
+	for {
+		if err := client.Query(context.Background(), &query, variables); err != nil {
+			return err
+		}
+		if err := query.Repository.PullRequests.Nodes.InRange(since, until, fn); err != nil {
+			return err
+		}
+		pageInfo := query.Repository.PullRequests.PageInfo
+		if !pageInfo.HasNextPage {
+			break
+		}
+		throttleIfLow(query.RateLimit, rateLimitThreshold)
+		variables["after"] = githubv4.String(pageInfo.EndCursor)
+	}
+	return nil
+}
+
+type CommitData struct {
+	AbbreviatedOid  string
+	CommittedDate   time.Time
+	MessageHeadline string
+	Author          struct {
+		Email string
+	}
+}
+
+type CommitNodes []struct {
+	Commit CommitData `graphql:"... on Commit"`
+}
+
+// Calls fn for each CommitData in a CommitNodes array
+func (nodes CommitNodes) Each(fn func(CommitData) error) error {
+	for _, node := range nodes {
+		if err := fn(node.Commit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Calls fn for each commit within given time interval to default branch of specified repo
+func repositoryCommits(client githubv4.Client, orgName string, repoName string, since time.Time, until time.Time, rateLimitThreshold int, fn func(CommitData) error) error {
+	var query struct {
+		Repository struct {
+			Name             string
+			DefaultBranchRef struct {
+				Target struct {
+					Commit struct {
+						History struct {
+							TotalCount int
+							PageInfo   struct {
+								EndCursor   string
+								HasNextPage bool
+							}
+							Nodes CommitNodes
+						} `graphql:"history(first: 100, after: $after, since: $since, until: $until)"`
+					} `graphql:"... on Commit"`
+				}
+			}
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit rateLimitInfo
+	}
+
+	variables := map[string]interface{}{
+		"owner": githubv4.String(orgName),
+		"name":  githubv4.String(repoName),
+		"after": (*githubv4.String)(nil), // first cursor is null
+		"since": githubv4.GitTimestamp{Time: since},
+		"until": githubv4.GitTimestamp{Time: until},
+	}
+
+	// Handle pagination
+	for {
+		if err := client.Query(context.Background(), &query, variables); err != nil {
+			return err
+		}
+		if err := query.Repository.DefaultBranchRef.Target.Commit.History.Nodes.Each(fn); err != nil {
+			return err
+		}
+		pageInfo := query.Repository.DefaultBranchRef.Target.Commit.History.PageInfo
+		if !pageInfo.HasNextPage {
+			break
+		}
+		throttleIfLow(query.RateLimit, rateLimitThreshold)
+		variables["after"] = githubv4.String(pageInfo.EndCursor)
+	}
+	return nil
+}
+
+// An issue, including its labels and assignees
+type IssueNode struct {
+	Number    int
+	Title     string
+	CreatedAt time.Time
+	ClosedAt  *time.Time
+	Author    struct {
+		Login string
+	}
+	Labels struct {
+		Nodes []struct {
+			Name string
+		}
+	} `graphql:"labels(first: 20)"`
+	Assignees struct {
+		Nodes []struct {
+			Login string
+		}
+	} `graphql:"assignees(first: 20)"`
+}
+
+type IssueNodes []IssueNode
+
+// Calls fn for each issue filed or closed within specified date range from an IssueNodes array
+func (nodes IssueNodes) InRange(since time.Time, until time.Time, fn func(IssueNode) error) error {
+	for _, node := range nodes {
+		filed := !node.CreatedAt.Before(since) && node.CreatedAt.Before(until)
+		closed := node.ClosedAt != nil && !node.ClosedAt.Before(since) && node.ClosedAt.Before(until)
+		if filed || closed {
+			if err := fn(node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Calls fn for each issue filed or closed in specified repo within the given time interval
+func repositoryIssues(client githubv4.Client, orgName string, repoName string, since time.Time, until time.Time, rateLimitThreshold int, fn func(IssueNode) error) error {
+	var query struct {
+		Repository struct {
+			Name   string
+			Issues struct {
+				TotalCount int
+				PageInfo   struct {
+					EndCursor   string
+					HasNextPage bool
+				}
+				Nodes IssueNodes
+			} `graphql:"issues(first: 100, after: $after, states: [OPEN, CLOSED])"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit rateLimitInfo
+	}
+
+	variables := map[string]interface{}{
+		"owner": githubv4.String(orgName),
+		"name":  githubv4.String(repoName),
+		"after": (*githubv4.String)(nil), // first cursor is null
+	}
+
+	// Handle pagination
+	for {
+		if err := client.Query(context.Background(), &query, variables); err != nil {
+			return err
+		}
+		if err := query.Repository.Issues.Nodes.InRange(since, until, fn); err != nil {
+			return err
+		}
+		pageInfo := query.Repository.Issues.PageInfo
+		if !pageInfo.HasNextPage {
+			break
+		}
+		throttleIfLow(query.RateLimit, rateLimitThreshold)
+		variables["after"] = githubv4.String(pageInfo.EndCursor)
+	}
+	return nil
+}
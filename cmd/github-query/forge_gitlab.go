@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const gitlabDefaultURL = "https://gitlab.com"
+
+// GitlabForge implements Forge using the GitLab REST API.
+type GitlabForge struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewGitlabForge returns a GitlabForge authenticated with the given personal access token.
+// If baseURL is empty, gitlab.com is used.
+func NewGitlabForge(baseURL string, token string) *GitlabForge {
+	if baseURL == "" {
+		baseURL = gitlabDefaultURL
+	}
+	return &GitlabForge{baseURL: baseURL, token: token, client: http.DefaultClient}
+}
+
+// Issues a GET request against the GitLab REST API and decodes the JSON response into v
+func (f *GitlabForge) get(path string, query url.Values, v interface{}) error {
+	u := f.baseURL + "/api/v4" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", f.token)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gitlab: %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+type gitlabProject struct {
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Archived       bool      `json:"archived"`
+	Visibility     string    `json:"visibility"`
+	CreatedAt      time.Time `json:"created_at"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
+
+// ListRepos returns the projects belonging to the specified group.
+func (f *GitlabForge) ListRepos(org string) ([]Repo, error) {
+	var repos []Repo
+	for page := 1; ; page++ {
+		var projects []gitlabProject
+		query := url.Values{"per_page": {"100"}, "page": {strconv.Itoa(page)}}
+		if err := f.get("/groups/"+url.PathEscape(org)+"/projects", query, &projects); err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			repos = append(repos, Repo{
+				Name:        p.Name,
+				Description: p.Description,
+				IsArchived:  p.Archived,
+				IsPrivate:   p.Visibility != "public",
+				CreatedAt:   p.CreatedAt,
+				PushedAt:    p.LastActivityAt,
+			})
+		}
+		if len(projects) < 100 {
+			break
+		}
+	}
+	return repos, nil
+}
+
+type gitlabCommit struct {
+	ShortID       string    `json:"short_id"`
+	CommittedDate time.Time `json:"committed_date"`
+	Title         string    `json:"title"`
+	AuthorEmail   string    `json:"author_email"`
+}
+
+// ListCommits calls fn for each commit within given time interval to default branch of org/repo.
+func (f *GitlabForge) ListCommits(org string, repo string, since time.Time, until time.Time, fn func(Commit) error) error {
+	projectID := url.PathEscape(org + "/" + repo)
+	for page := 1; ; page++ {
+		var gitlabCommits []gitlabCommit
+		query := url.Values{
+			"since":    {since.Format(time.RFC3339)},
+			"until":    {until.Format(time.RFC3339)},
+			"per_page": {"100"},
+			"page":     {strconv.Itoa(page)},
+		}
+		if err := f.get("/projects/"+projectID+"/repository/commits", query, &gitlabCommits); err != nil {
+			return err
+		}
+		for _, c := range gitlabCommits {
+			commit := Commit{
+				Oid:             c.ShortID,
+				CommittedDate:   c.CommittedDate,
+				MessageHeadline: c.Title,
+				AuthorEmail:     c.AuthorEmail,
+			}
+			if err := fn(commit); err != nil {
+				return err
+			}
+		}
+		if len(gitlabCommits) < 100 {
+			break
+		}
+	}
+	return nil
+}
+
+type gitlabMergeRequest struct {
+	IID          int        `json:"iid"`
+	Title        string     `json:"title"`
+	SourceBranch string     `json:"source_branch"`
+	MergedAt     *time.Time `json:"merged_at"`
+	Author       struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	MergeCommitSHA string `json:"merge_commit_sha"`
+}
+
+// ListPullRequests calls fn for each merge request that was merged to org/repo within the given time interval.
+//
+// updated_after/updated_before bound the merge request's last-updated time,
+// not its merge time, so a request merged inside [since, until) but commented
+// on afterward can fall outside the server-side window; the MergedAt re-check
+// below only guards against the opposite case (an update in range that merged
+// outside it). GerritForge.ListPullRequests, by contrast, sets no upper bound
+// server-side and so does not miss such changes.
+func (f *GitlabForge) ListPullRequests(org string, repo string, since time.Time, until time.Time, fn func(PullRequest) error) error {
+	projectID := url.PathEscape(org + "/" + repo)
+	for page := 1; ; page++ {
+		var mrs []gitlabMergeRequest
+		query := url.Values{
+			"state":          {"merged"},
+			"updated_after":  {since.Format(time.RFC3339)},
+			"updated_before": {until.Format(time.RFC3339)},
+			"per_page":       {"100"},
+			"page":           {strconv.Itoa(page)},
+		}
+		if err := f.get("/projects/"+projectID+"/merge_requests", query, &mrs); err != nil {
+			return err
+		}
+		for _, mr := range mrs {
+			if mr.MergedAt == nil || mr.MergedAt.Before(since) || !mr.MergedAt.Before(until) {
+				continue
+			}
+			pr := PullRequest{
+				Number:         mr.IID,
+				MergedAt:       *mr.MergedAt,
+				HeadRefName:    mr.SourceBranch,
+				Title:          mr.Title,
+				Author:         mr.Author.Username,
+				MergeCommitOid: mr.MergeCommitSHA,
+			}
+			if err := fn(pr); err != nil {
+				return err
+			}
+		}
+		if len(mrs) < 100 {
+			break
+		}
+	}
+	return nil
+}
+
+type gitlabIssue struct {
+	IID    int    `json:"iid"`
+	Title  string `json:"title"`
+	Author struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	Labels    []string `json:"labels"`
+	Assignees []struct {
+		Username string `json:"username"`
+	} `json:"assignees"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+}
+
+// ListIssues calls fn for each issue filed or closed in org/repo within the given time interval.
+func (f *GitlabForge) ListIssues(org string, repo string, since time.Time, until time.Time, fn func(Issue) error) error {
+	projectID := url.PathEscape(org + "/" + repo)
+	for page := 1; ; page++ {
+		var issues []gitlabIssue
+		query := url.Values{
+			// No updated_before: an issue filed or closed before until may have
+			// been updated after it. The filed/closed check below bounds until.
+			"updated_after": {since.Format(time.RFC3339)},
+			"per_page":      {"100"},
+			"page":          {strconv.Itoa(page)},
+		}
+		if err := f.get("/projects/"+projectID+"/issues", query, &issues); err != nil {
+			return err
+		}
+		for _, i := range issues {
+			filed := !i.CreatedAt.Before(since) && i.CreatedAt.Before(until)
+			closed := i.ClosedAt != nil && !i.ClosedAt.Before(since) && i.ClosedAt.Before(until)
+			if !filed && !closed {
+				continue
+			}
+			issue := Issue{
+				Number: i.IID,
+				Title:  i.Title,
+				Author: i.Author.Username,
+				Labels: i.Labels,
+			}
+			for _, a := range i.Assignees {
+				issue.Assignees = append(issue.Assignees, a.Username)
+			}
+			if i.ClosedAt != nil {
+				issue.ClosedAt = *i.ClosedAt
+			}
+			if err := fn(issue); err != nil {
+				return err
+			}
+		}
+		if len(issues) < 100 {
+			break
+		}
+	}
+	return nil
+}
@@ -1,337 +1,300 @@
 package main
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
-	"flag"
 	"fmt"
-	"io"
 	"os"
+	"path/filepath"
 	"time"
 
-	"github.com/shurcooL/githubv4"
-	"golang.org/x/oauth2"
+	"github.com/urfave/cli/v2"
 )
 
 var (
-	listType string
-	orgName  string
-	repoName string
-	sinceStr string
-	untilStr string
+	orgName            string
+	repoName           string
+	sinceStr           string
+	untilStr           string
+	forgeName          string
+	cacheDirOpt        string
+	noCache            bool
+	rateLimitThreshold int
+	outputFormat       string
 )
 
-func init() {
-	flag.StringVar(&listType, "list", "", "<repos|commits|pull-requests> (Required)")
-	flag.StringVar(&orgName, "org", "", "Organization name (Required)")
-	flag.StringVar(&repoName, "repo", "", "Repository name (Required except to list repos)")
-	flag.StringVar(&sinceStr, "since", "", "Start of date range (YYYY-MM-DD)")
-	flag.StringVar(&untilStr, "until", "", "End of date range (YYYY-MM-DD)")
+// buildForge constructs the selected Forge using the resolved global flags.
+func buildForge() (Forge, error) {
+	return newForge(forgeName, forgeOptions{
+		cacheDir:           effectiveCacheDir(),
+		rateLimitThreshold: rateLimitThreshold,
+	})
 }
 
-func main() {
-	flag.Parse()
-	// TODO: complain about unused args
-
-	if err := validateOptions(listType, orgName, repoName); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		printUsage()
-	}
-
-	since := parseDate(sinceStr, time.Unix(0, 0)) // default is 1970-01-01
-	until := parseDate(untilStr, time.Now())
-
-	token, ok := os.LookupEnv("GITHUB_TOKEN")
-	if !ok {
-		fmt.Fprintln(os.Stderr, "Missing environment variable: GITHUB_TOKEN")
-		printUsage()
+// defaultCacheDir returns $XDG_CACHE_HOME/gotools/github (or the platform
+// equivalent), or "" if it cannot be determined.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
 	}
-	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	client := githubv4.NewClient(oauth2.NewClient(context.Background(), tokenSource))
+	return filepath.Join(dir, "gotools", "github")
+}
 
-	cmd := func() (interface{}, error) {
-		switch listType {
-		case "repos":
-			return organizationRepositoryNames(*client, orgName)
-		case "commits":
-			return repositoryCommits(*client, orgName, repoName, since, until)
-		case "pull-requests":
-			return repositoryPullRequests(*client, orgName, repoName, since, until)
-		default:
-			panic("invalid list type")
-		}
+// effectiveCacheDir returns the cache directory to use, or "" if caching is disabled.
+func effectiveCacheDir() string {
+	if noCache {
+		return ""
 	}
+	return cacheDirOpt
+}
 
-	res, err := cmd()
-	if err != nil {
+func main() {
+	if err := app().Run(os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	printJSON(res, os.Stdout)
 }
 
-// Prints usage and exits
-func printUsage() {
-	flag.Usage()
-	fmt.Fprintln(os.Stderr, "\nNote: GITHUB_TOKEN environment variable is required.")
-	os.Exit(1)
+// app builds the github-query command line application.
+func app() *cli.App {
+	return &cli.App{
+		Name:  "github-query",
+		Usage: "Query repository activity across forges",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "forge",
+				Value:       "github",
+				Usage:       "<github|gitlab|gerrit> forge to query",
+				Destination: &forgeName,
+			},
+			&cli.StringFlag{
+				Name:        "org",
+				Usage:       "Organization name (Required)",
+				Required:    true,
+				Destination: &orgName,
+			},
+			&cli.StringFlag{
+				Name:        "cache-dir",
+				Value:       defaultCacheDir(),
+				Usage:       "Directory for the on-disk GitHub API response cache",
+				Destination: &cacheDirOpt,
+			},
+			&cli.BoolFlag{
+				Name:        "no-cache",
+				Usage:       "Disable the on-disk GitHub API response cache",
+				Destination: &noCache,
+			},
+			&cli.IntFlag{
+				Name:        "rate-limit-threshold",
+				Value:       defaultRateLimitThreshold,
+				Usage:       "Minimum remaining GitHub GraphQL quota to keep in reserve",
+				Destination: &rateLimitThreshold,
+			},
+			&cli.StringFlag{
+				Name:        "output",
+				Value:       "json",
+				Usage:       "<json|ndjson|csv|yaml> output format",
+				Destination: &outputFormat,
+			},
+		},
+		Commands: []*cli.Command{
+			reposCommand,
+			commitsCommand,
+			pullRequestsCommand,
+			issuesCommand,
+			reviewersCommand,
+		},
+	}
 }
 
-func validateOptions(listOpt string, orgOpt string, repoOpt string) error {
-	if listOpt == "" {
-		return errors.New("Missing option: list")
-	}
-	if orgOpt == "" {
-		return errors.New("Missing option: org")
-	}
-	switch listOpt {
-	case "repos":
-		if repoOpt != "" {
-			return errors.New("Incompatible option: repo")
+var reposCommand = &cli.Command{
+	Name:  "repos",
+	Usage: "List the repos belonging to an organization",
+	Action: func(c *cli.Context) error {
+		forge, err := buildForge()
+		if err != nil {
+			return err
 		}
-		// TODO: complain about unused since or until?
-	case "commits", "pull-requests":
-		if repoOpt == "" {
-			return errors.New("Missing option: repo")
+		repos, err := forge.ListRepos(orgName)
+		if err != nil {
+			return err
 		}
-		break
-	default:
-		return fmt.Errorf("Invalid list option: %s", listOpt)
-	}
-	return nil
-}
-
-// Parses the given timestr if not empty, else returns the provided default value.
-// The time string is expected to conform to YYYY-MM-DD (ISO 8601) format.
-func parseDate(timestr string, timedef time.Time) time.Time {
-	if timestr != "" {
-		since, err := time.Parse("2006-01-02", timestr)
+		enc, err := newEncoder(outputFormat, os.Stdout)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			printUsage()
+			return err
 		}
-		return since
-	}
-	return timedef
-}
-
-// Prints v as JSON to writer w. Panics on any error.
-func printJSON(v interface{}, w io.Writer) {
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(v); err != nil {
-		panic(err)
-	}
-}
-
-type RepositoryNodes []struct {
-	Name        string
-	Description string
-	IsArchived  bool
-	IsPrivate   bool
-	CreatedAt   time.Time
-	PushedAt    time.Time
-}
-
-// Collects repository names from a RepositoryNodes array
-func (nodes RepositoryNodes) Names() (names []string) {
-	for _, repo := range nodes {
-		names = append(names, repo.Name)
-	}
-	return
+		for _, repo := range repos {
+			if err := enc.Encode(repo); err != nil {
+				return err
+			}
+		}
+		return enc.Close()
+	},
 }
 
-// Returns the names of the repos belonging to the specified organization
-func organizationRepositoryNames(client githubv4.Client, orgName string) ([]string, error) {
-	var query struct {
-		Organization struct {
-			Repositories struct {
-				TotalCount int
-				PageInfo   struct {
-					EndCursor   string
-					HasNextPage bool
-				}
-				Nodes RepositoryNodes
-			} `graphql:"repositories(first: 100, after: $after, orderBy: {field: NAME, direction: ASC})"`
-		} `graphql:"organization(login: $login)"`
-		RateLimit struct {
-			Cost      int
-			Limit     int
-			Remaining int
-			ResetAt   time.Time
+var commitsCommand = &cli.Command{
+	Name:  "commits",
+	Usage: "List commits to the default branch of a repo within a date range",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "repo", Usage: "Repository name (Required)", Destination: &repoName},
+		&cli.StringFlag{Name: "since", Usage: "Start of date range (YYYY-MM-DD or RFC3339)", Destination: &sinceStr},
+		&cli.StringFlag{Name: "until", Usage: "End of date range (YYYY-MM-DD or RFC3339)", Destination: &untilStr},
+	},
+	Before: requireRepoFlag,
+	Action: func(c *cli.Context) error {
+		forge, err := buildForge()
+		if err != nil {
+			return err
 		}
-	}
-
-	variables := map[string]interface{}{
-		"login": githubv4.String(orgName),
-		"after": (*githubv4.String)(nil), // first cursor is null
-	}
-
-	// Handle pagination
-	var names []string
-	for {
-		if err := client.Query(context.Background(), &query, variables); err != nil {
-			return nil, err
+		since, err := parseDate(sinceStr, time.Unix(0, 0)) // default is 1970-01-01
+		if err != nil {
+			return err
 		}
-		names = append(names, query.Organization.Repositories.Nodes.Names()...)
-		pageInfo := query.Organization.Repositories.PageInfo
-		if !pageInfo.HasNextPage {
-			break
+		until, err := parseDate(untilStr, time.Now())
+		if err != nil {
+			return err
 		}
-		variables["after"] = githubv4.String(pageInfo.EndCursor)
-	}
-	return names, nil
-}
-
-// A merged pull request, including the merge commit and approving reviews
-type PullRequestNode struct {
-	Number      int
-	MergedAt    time.Time
-	HeadRefName string
-	Title       string
-	Author      struct {
-		Login string
-	}
-	MergeCommit struct {
-		MessageHeadline string
-		AbbreviatedOid  string
-	}
-	Reviews struct {
-		Nodes []struct {
-			SubmittedAt time.Time
-			Author      struct {
-				Login string
-			}
+		enc, err := newEncoder(outputFormat, os.Stdout)
+		if err != nil {
+			return err
 		}
-	} `graphql:"reviews(first: 2, states: APPROVED)"`
-}
-
-type PullRequestNodes []PullRequestNode
-
-// Collects pull requests within specified date range from a PullRequestNodes array
-func (nodes PullRequestNodes) InRange(since time.Time, until time.Time) (list PullRequestNodes) {
-	for _, node := range nodes {
-		if !node.MergedAt.Before(since) && node.MergedAt.Before(until) {
-			list = append(list, node)
+		if err := forge.ListCommits(orgName, repoName, since, until, func(commit Commit) error {
+			return enc.Encode(commit)
+		}); err != nil {
+			return err
 		}
-	}
-	return
+		return enc.Close()
+	},
 }
 
-// Returns annotated pull requests that were merged to specified repo within the given time interval
-func repositoryPullRequests(client githubv4.Client, orgName string, repoName string, since time.Time, until time.Time) ([]PullRequestNode, error) {
-	var query struct {
-		Repository struct {
-			Name         string
-			PullRequests struct {
-				TotalCount int
-				PageInfo   struct {
-					EndCursor   string
-					HasNextPage bool
-				}
-				Nodes PullRequestNodes
-			} `graphql:"pullRequests(first: 100, after: $after)"`
-		} `graphql:"repository(owner: $owner, name: $name)"`
-		RateLimit struct {
-			Cost      int
-			Limit     int
-			Remaining int
-			ResetAt   time.Time
+var pullRequestsCommand = &cli.Command{
+	Name:  "pull-requests",
+	Usage: "List pull requests merged to a repo within a date range",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "repo", Usage: "Repository name (Required)", Destination: &repoName},
+		&cli.StringFlag{Name: "since", Usage: "Start of date range (YYYY-MM-DD or RFC3339)", Destination: &sinceStr},
+		&cli.StringFlag{Name: "until", Usage: "End of date range (YYYY-MM-DD or RFC3339)", Destination: &untilStr},
+	},
+	Before: requireRepoFlag,
+	Action: func(c *cli.Context) error {
+		forge, err := buildForge()
+		if err != nil {
+			return err
 		}
-	}
-
-	variables := map[string]interface{}{
-		"owner": githubv4.String(orgName),
-		"name":  githubv4.String(repoName),
-		"after": (*githubv4.String)(nil), // first cursor is null
-	}
-
-	// Handle pagination
-	var pullRequests []PullRequestNode
-	for {
-		if err := client.Query(context.Background(), &query, variables); err != nil {
-			return nil, err
+		since, err := parseDate(sinceStr, time.Unix(0, 0)) // default is 1970-01-01
+		if err != nil {
+			return err
 		}
-		pullRequests = append(pullRequests, query.Repository.PullRequests.Nodes.InRange(since, until)...)
-		pageInfo := query.Repository.PullRequests.PageInfo
-		if !pageInfo.HasNextPage {
-			break
+		until, err := parseDate(untilStr, time.Now())
+		if err != nil {
+			return err
 		}
-		variables["after"] = githubv4.String(pageInfo.EndCursor)
-	}
-	return pullRequests, nil
+		enc, err := newEncoder(outputFormat, os.Stdout)
+		if err != nil {
+			return err
+		}
+		if err := forge.ListPullRequests(orgName, repoName, since, until, func(pr PullRequest) error {
+			return enc.Encode(pr)
+		}); err != nil {
+			return err
+		}
+		return enc.Close()
+	},
 }
 
-type CommitData struct {
-	AbbreviatedOid  string
-	CommittedDate   time.Time
-	MessageHeadline string
-	Author          struct {
-		Email string
-	}
+var issuesCommand = &cli.Command{
+	Name:  "issues",
+	Usage: "List issues filed or closed in a repo within a date range",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "repo", Usage: "Repository name (Required)", Destination: &repoName},
+		&cli.StringFlag{Name: "since", Usage: "Start of date range (YYYY-MM-DD or RFC3339)", Destination: &sinceStr},
+		&cli.StringFlag{Name: "until", Usage: "End of date range (YYYY-MM-DD or RFC3339)", Destination: &untilStr},
+	},
+	Before: requireRepoFlag,
+	Action: func(c *cli.Context) error {
+		forge, err := buildForge()
+		if err != nil {
+			return err
+		}
+		since, err := parseDate(sinceStr, time.Unix(0, 0)) // default is 1970-01-01
+		if err != nil {
+			return err
+		}
+		until, err := parseDate(untilStr, time.Now())
+		if err != nil {
+			return err
+		}
+		enc, err := newEncoder(outputFormat, os.Stdout)
+		if err != nil {
+			return err
+		}
+		if err := forge.ListIssues(orgName, repoName, since, until, func(issue Issue) error {
+			return enc.Encode(issue)
+		}); err != nil {
+			return err
+		}
+		return enc.Close()
+	},
 }
 
-type CommitNodes []struct {
-	Commit CommitData `graphql:"... on Commit"`
+var reviewersCommand = &cli.Command{
+	Name:  "reviewers",
+	Usage: "Aggregate approving reviews of merged pull requests in a repo within a date range",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "repo", Usage: "Repository name (Required)", Destination: &repoName},
+		&cli.StringFlag{Name: "since", Usage: "Start of date range (YYYY-MM-DD or RFC3339)", Destination: &sinceStr},
+		&cli.StringFlag{Name: "until", Usage: "End of date range (YYYY-MM-DD or RFC3339)", Destination: &untilStr},
+	},
+	Before: requireRepoFlag,
+	Action: func(c *cli.Context) error {
+		forge, err := buildForge()
+		if err != nil {
+			return err
+		}
+		since, err := parseDate(sinceStr, time.Unix(0, 0)) // default is 1970-01-01
+		if err != nil {
+			return err
+		}
+		until, err := parseDate(untilStr, time.Now())
+		if err != nil {
+			return err
+		}
+		stats, err := aggregateReviewers(forge, orgName, repoName, since, until)
+		if err != nil {
+			return err
+		}
+		enc, err := newEncoder(outputFormat, os.Stdout)
+		if err != nil {
+			return err
+		}
+		for _, stat := range stats {
+			if err := enc.Encode(stat); err != nil {
+				return err
+			}
+		}
+		return enc.Close()
+	},
 }
 
-// Collects CommitData from a CommitNodes array
-func (nodes CommitNodes) Commits() (commits []CommitData) {
-	for _, node := range nodes {
-		commits = append(commits, node.Commit)
+// requireRepoFlag is a Before hook that rejects a missing --repo flag.
+func requireRepoFlag(c *cli.Context) error {
+	if repoName == "" {
+		return errors.New("Missing option: repo")
 	}
-	return
+	return nil
 }
 
-// Returns commits within given time interval to default branch of specified repo
-func repositoryCommits(client githubv4.Client, orgName string, repoName string, since time.Time, until time.Time) ([]CommitData, error) {
-	var query struct {
-		Repository struct {
-			Name             string
-			DefaultBranchRef struct {
-				Target struct {
-					Commit struct {
-						History struct {
-							TotalCount int
-							PageInfo   struct {
-								EndCursor   string
-								HasNextPage bool
-							}
-							Nodes CommitNodes
-						} `graphql:"history(first: 100, after: $after, since: $since, until: $until)"`
-					} `graphql:"... on Commit"`
-				}
-			}
-		} `graphql:"repository(owner: $owner, name: $name)"`
-		RateLimit struct {
-			Cost      int
-			Limit     int
-			Remaining int
-			ResetAt   time.Time
-		}
+// Parses the given timestr if not empty, else returns the provided default value.
+// The time string is expected to conform to YYYY-MM-DD (ISO 8601) or RFC3339 format.
+func parseDate(timestr string, timedef time.Time) (time.Time, error) {
+	if timestr == "" {
+		return timedef, nil
 	}
-
-	variables := map[string]interface{}{
-		"owner": githubv4.String(orgName),
-		"name":  githubv4.String(repoName),
-		"after": (*githubv4.String)(nil), // first cursor is null
-		"since": githubv4.GitTimestamp{Time: since},
-		"until": githubv4.GitTimestamp{Time: until},
+	if t, err := time.Parse("2006-01-02", timestr); err == nil {
+		return t, nil
 	}
-
-	// Handle pagination
-	var commits []CommitData
-	for {
-		if err := client.Query(context.Background(), &query, variables); err != nil {
-			return nil, err
-		}
-		commits = append(commits, query.Repository.DefaultBranchRef.Target.Commit.History.Nodes.Commits()...)
-		pageInfo := query.Repository.DefaultBranchRef.Target.Commit.History.PageInfo
-		if !pageInfo.HasNextPage {
-			break
-		}
-		variables["after"] = githubv4.String(pageInfo.EndCursor)
+	if t, err := time.Parse(time.RFC3339, timestr); err == nil {
+		return t, nil
 	}
-	return commits, nil
+	return time.Time{}, fmt.Errorf("Invalid date: %s", timestr)
 }
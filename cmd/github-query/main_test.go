@@ -5,31 +5,132 @@ import (
 	"time"
 )
 
-func TestValidateOptions(t *testing.T) {
+func TestRequireRepoFlag(t *testing.T) {
 	tests := []struct {
 		name    string
-		listOpt string
-		orgOpt  string
 		repoOpt string
 		valid   bool
 	}{
-		{"1", "", "", "", false},
-		{"2", "", "myOrg", "myRepo", false},
-		{"3", "repos", "", "", false},
-		{"4", "repos", "myOrg", "", true},
-		{"5", "repos", "myOrg", "myRepo", false},
-		{"6", "commits", "", "", false},
-		{"7", "commits", "myOrg", "", false},
-		{"8", "commits", "myOrg", "myRepo", true},
-		{"9", "pull-requests", "", "myRepo", false},
-		{"10", "pull-requests", "myOrg", "myRepo", true},
-		{"11", "foobar", "", "", false},
-		{"12", "foobar", "myOrg", "", false},
+		{"missing", "", false},
+		{"present", "myRepo", true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := validateOptions(tt.listOpt, tt.orgOpt, tt.repoOpt); err == nil != tt.valid {
-				t.Errorf("validateOptions err=[%v], wanted valid=%v", err, tt.valid)
+			repoName = tt.repoOpt
+			if err := requireRepoFlag(nil); err == nil != tt.valid {
+				t.Errorf("requireRepoFlag err=[%v], wanted valid=%v", err, tt.valid)
+			}
+		})
+	}
+}
+
+// stubForge is a Forge that replays a fixed list of pull requests, for
+// testing aggregation logic without a live backend.
+type stubForge struct {
+	prs []PullRequest
+}
+
+func (s stubForge) ListRepos(org string) ([]Repo, error) { return nil, nil }
+func (s stubForge) ListCommits(org string, repo string, since time.Time, until time.Time, fn func(Commit) error) error {
+	return nil
+}
+func (s stubForge) ListPullRequests(org string, repo string, since time.Time, until time.Time, fn func(PullRequest) error) error {
+	for _, pr := range s.prs {
+		if err := fn(pr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (s stubForge) ListIssues(org string, repo string, since time.Time, until time.Time, fn func(Issue) error) error {
+	return nil
+}
+
+func TestAggregateReviewers(t *testing.T) {
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	t3 := time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)
+	forge := stubForge{prs: []PullRequest{
+		{
+			Number: 1,
+			Approvals: []Approval{
+				{Author: "alice", SubmittedAt: t1},
+				{Author: "bob", SubmittedAt: t2},
+			},
+		},
+		{
+			// alice approves twice on the same PR: Approvals counts both,
+			// PRsReviewed counts the PR once.
+			Number: 2,
+			Approvals: []Approval{
+				{Author: "alice", SubmittedAt: t2},
+				{Author: "alice", SubmittedAt: t3},
+			},
+		},
+	}}
+
+	stats, err := aggregateReviewers(forge, "org", "repo", t1, t3.Add(time.Second))
+	if err != nil {
+		t.Fatalf("aggregateReviewers returned err: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("got %d logins, wanted 2: %+v", len(stats), stats)
+	}
+	// ordered by login
+	alice, bob := stats[0], stats[1]
+	if alice.Login != "alice" || bob.Login != "bob" {
+		t.Fatalf("got logins %q, %q, wanted alice, bob", alice.Login, bob.Login)
+	}
+	if alice.Approvals != 3 {
+		t.Errorf("alice.Approvals = %d, wanted 3", alice.Approvals)
+	}
+	if alice.PRsReviewed != 2 {
+		t.Errorf("alice.PRsReviewed = %d, wanted 2", alice.PRsReviewed)
+	}
+	if !alice.FirstReviewAt.Equal(t1) {
+		t.Errorf("alice.FirstReviewAt = %v, wanted %v", alice.FirstReviewAt, t1)
+	}
+	if !alice.LastReviewAt.Equal(t3) {
+		t.Errorf("alice.LastReviewAt = %v, wanted %v", alice.LastReviewAt, t3)
+	}
+	if bob.Approvals != 1 || bob.PRsReviewed != 1 {
+		t.Errorf("bob stats = %+v, wanted Approvals=1, PRsReviewed=1", bob)
+	}
+}
+
+func TestIssueNodesInRange(t *testing.T) {
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	before := since.AddDate(0, 0, -1)
+	inside := since.AddDate(0, 0, 1)
+	after := until
+
+	tests := []struct {
+		name    string
+		created time.Time
+		closed  *time.Time
+		want    bool
+	}{
+		{"filed inside, never closed", inside, nil, true},
+		{"filed before, never closed", before, nil, false},
+		{"filed before, closed inside", before, &inside, true},
+		{"filed before, closed before", before, &before, false},
+		{"filed before, closed after", before, &after, false},
+		{"filed after, closed inside", after, &inside, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := IssueNodes{{CreatedAt: tt.created, ClosedAt: tt.closed}}
+			var got []IssueNode
+			err := nodes.InRange(since, until, func(n IssueNode) error {
+				got = append(got, n)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("InRange returned err: %v", err)
+			}
+			if matched := len(got) == 1; matched != tt.want {
+				t.Errorf("InRange matched = %v, wanted %v", matched, tt.want)
 			}
 		})
 	}
@@ -39,21 +140,28 @@ func TestParseDate(t *testing.T) {
 	now := time.Now()
 	jul := time.Date(2018, 7, 1, 0, 0, 0, 0, time.UTC)
 	jan := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	rfc := time.Date(2020, 3, 4, 15, 6, 7, 0, time.UTC)
 	tests := []struct {
 		name    string
 		timestr string
 		timedef time.Time
 		parsed  time.Time
+		wantErr bool
 	}{
-		{"1", "", now, now},
-		{"2", "2018-07-01", now, jul},
-		{"3", "2018-07-01", jan, jul},
-		{"4", "2019-01-01", jul, jan},
+		{"1", "", now, now, false},
+		{"2", "2018-07-01", now, jul, false},
+		{"3", "2018-07-01", jan, jul, false},
+		{"4", "2019-01-01", jul, jan, false},
+		{"5", "2020-03-04T15:06:07Z", jul, rfc, false},
+		{"6", "not-a-date", jul, time.Time{}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			v := parseDate(tt.timestr, tt.timedef)
-			if v != tt.parsed {
+			v, err := parseDate(tt.timestr, tt.timedef)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDate err=[%v], wantErr=%v", err, tt.wantErr)
+			}
+			if err == nil && !v.Equal(tt.parsed) {
 				t.Errorf("parseDate got %v, wanted %v", v, tt.parsed)
 			}
 		})
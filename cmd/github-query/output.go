@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Encoder writes a stream of records to an underlying writer in a particular
+// output format. Encode may be called once per record as records become
+// available (e.g. while paginating); Close finalizes the output.
+type Encoder interface {
+	Encode(v interface{}) error
+	Close() error
+}
+
+// newEncoder returns the Encoder for the named output format: "json" (the
+// default), "ndjson", "csv" or "yaml".
+func newEncoder(format string, w io.Writer) (Encoder, error) {
+	switch format {
+	case "", "json":
+		return &jsonEncoder{w: w}, nil
+	case "ndjson":
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	case "csv":
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	case "yaml":
+		return &yamlEncoder{w: w}, nil
+	default:
+		return nil, fmt.Errorf("Invalid output option: %s", format)
+	}
+}
+
+// jsonEncoder buffers all records and writes a single indented JSON array on Close.
+type jsonEncoder struct {
+	w       io.Writer
+	records []interface{}
+}
+
+func (e *jsonEncoder) Encode(v interface{}) error {
+	e.records = append(e.records, v)
+	return nil
+}
+
+func (e *jsonEncoder) Close() error {
+	enc := json.NewEncoder(e.w)
+	enc.SetIndent("", "  ")
+	if e.records == nil {
+		e.records = []interface{}{}
+	}
+	return enc.Encode(e.records)
+}
+
+// ndjsonEncoder writes one JSON object per line as each record arrives.
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(v interface{}) error {
+	return e.enc.Encode(v)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// yamlEncoder buffers all records and writes a single YAML sequence on Close.
+type yamlEncoder struct {
+	w       io.Writer
+	records []interface{}
+}
+
+func (e *yamlEncoder) Encode(v interface{}) error {
+	e.records = append(e.records, v)
+	return nil
+}
+
+func (e *yamlEncoder) Close() error {
+	return yaml.NewEncoder(e.w).Encode(e.records)
+}
+
+// csvEncoder flattens Repo, Commit, PullRequest, Issue and ReviewerStats records into RFC4180 rows,
+// writing a header row with a stable column order before the first record of
+// each type.
+type csvEncoder struct {
+	w       *csv.Writer
+	headers map[string]bool
+}
+
+func (e *csvEncoder) Encode(v interface{}) error {
+	var header, row []string
+	switch r := v.(type) {
+	case Repo:
+		header = []string{"name", "description", "is_archived", "is_private", "created_at", "pushed_at"}
+		row = []string{r.Name, r.Description, strconv.FormatBool(r.IsArchived), strconv.FormatBool(r.IsPrivate),
+			r.CreatedAt.Format(time.RFC3339), r.PushedAt.Format(time.RFC3339)}
+	case Commit:
+		header = []string{"oid", "committed_date", "message_headline", "author_email"}
+		row = []string{r.Oid, r.CommittedDate.Format(time.RFC3339), r.MessageHeadline, r.AuthorEmail}
+	case PullRequest:
+		header = []string{"number", "merged_at", "head_ref_name", "title", "author", "merge_commit_oid", "merge_commit_headline"}
+		row = []string{strconv.Itoa(r.Number), r.MergedAt.Format(time.RFC3339), r.HeadRefName, r.Title, r.Author,
+			r.MergeCommitOid, r.MergeCommitHeadline}
+	case Issue:
+		header = []string{"number", "title", "author", "labels", "assignees", "closed_at"}
+		row = []string{strconv.Itoa(r.Number), r.Title, r.Author, strings.Join(r.Labels, ";"), strings.Join(r.Assignees, ";"),
+			r.ClosedAt.Format(time.RFC3339)}
+	case ReviewerStats:
+		header = []string{"login", "approvals", "prs_reviewed", "first_review_at", "last_review_at"}
+		row = []string{r.Login, strconv.Itoa(r.Approvals), strconv.Itoa(r.PRsReviewed),
+			r.FirstReviewAt.Format(time.RFC3339), r.LastReviewAt.Format(time.RFC3339)}
+	default:
+		return fmt.Errorf("csv: unsupported record type %T", v)
+	}
+
+	if e.headers == nil {
+		e.headers = make(map[string]bool)
+	}
+	key := fmt.Sprintf("%T", v)
+	if !e.headers[key] {
+		e.headers[key] = true
+		if err := e.w.Write(header); err != nil {
+			return err
+		}
+	}
+	return e.w.Write(row)
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
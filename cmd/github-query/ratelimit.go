@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultRateLimitThreshold is the minimum quota, in points, to keep in reserve
+// before issuing the next paginated GraphQL request.
+const defaultRateLimitThreshold = 100
+
+const (
+	rateLimitBackoffBase = 1 * time.Second
+	rateLimitBackoffCap  = 60 * time.Second
+	rateLimitMaxRetries  = 5
+)
+
+// rateLimitInfo mirrors the RateLimit block requested alongside each GraphQL query.
+type rateLimitInfo struct {
+	Cost      int
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+}
+
+// throttleIfLow sleeps until info.ResetAt if the remaining quota is below
+// threshold or insufficient to cover another request of the same cost,
+// logging a structured throttle event first. threshold <= 0 selects
+// defaultRateLimitThreshold.
+func throttleIfLow(info rateLimitInfo, threshold int) {
+	if threshold <= 0 {
+		threshold = defaultRateLimitThreshold
+	}
+	if info.Remaining >= threshold && info.Cost <= info.Remaining {
+		return
+	}
+	wait := time.Until(info.ResetAt)
+	if wait <= 0 {
+		return
+	}
+	logThrottle(fmt.Sprintf("rate limit low (remaining=%d cost=%d limit=%d resetAt=%s)", info.Remaining, info.Cost, info.Limit, info.ResetAt.Format(time.RFC3339)), wait)
+	time.Sleep(wait)
+}
+
+// rateLimitTransport retries requests that fail with an HTTP 403 or 429 secondary
+// rate limit response, backing off exponentially with jitter between attempts.
+type rateLimitTransport struct {
+	next http.RoundTripper
+}
+
+// newRateLimitTransport wraps next (or http.DefaultTransport if nil) with
+// secondary rate limit backoff.
+func newRateLimitTransport(next http.RoundTripper) *rateLimitTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &rateLimitTransport{next: next}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || (resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests) {
+			return resp, err
+		}
+		respBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return resp, readErr
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+		if !isSecondaryRateLimit(resp, respBody) || attempt >= rateLimitMaxRetries {
+			return resp, nil
+		}
+		wait := retryAfterDelay(resp, attempt)
+		logThrottle(fmt.Sprintf("secondary rate limit (status=%d attempt=%d/%d)", resp.StatusCode, attempt+1, rateLimitMaxRetries), wait)
+		time.Sleep(wait)
+	}
+}
+
+// isSecondaryRateLimit reports whether resp signals GitHub's secondary rate
+// limit / abuse detection mechanism, as opposed to an ordinary 403 (e.g. bad
+// credentials or insufficient scope) that retrying won't fix. GitHub signals
+// this with a 429, a Retry-After header, or a 403 body naming the mechanism.
+func isSecondaryRateLimit(resp *http.Response, body []byte) bool {
+	if resp.StatusCode == http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "" {
+		return true
+	}
+	lower := bytes.ToLower(body)
+	return bytes.Contains(lower, []byte("abuse detection")) || bytes.Contains(lower, []byte("secondary rate limit"))
+}
+
+// retryAfterDelay computes the backoff before the next retry, honoring a
+// Retry-After header if present, else exponential backoff with jitter.
+func retryAfterDelay(resp *http.Response, attempt int) time.Duration {
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if when, err := http.ParseTime(ra); err == nil {
+			if d := time.Until(when); d > 0 {
+				return d
+			}
+		}
+	}
+	wait := rateLimitBackoffBase << attempt
+	if wait > rateLimitBackoffCap {
+		wait = rateLimitBackoffCap
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait)/2+1))
+}
+
+// logThrottle emits a structured throttle event to stderr.
+func logThrottle(reason string, wait time.Duration) {
+	fmt.Fprintf(os.Stderr, "throttle: %s; sleeping %s\n", reason, wait.Round(time.Millisecond))
+}
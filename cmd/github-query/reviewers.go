@@ -0,0 +1,59 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// ReviewerStats aggregates one login's approving reviews over pull requests
+// merged within a date range.
+type ReviewerStats struct {
+	Login         string
+	Approvals     int
+	PRsReviewed   int
+	FirstReviewAt time.Time
+	LastReviewAt  time.Time
+}
+
+// aggregateReviewers lists pull requests merged to org/repo within [since, until)
+// and aggregates approving reviews per login, ordered by login.
+func aggregateReviewers(forge Forge, org string, repo string, since time.Time, until time.Time) ([]ReviewerStats, error) {
+	byLogin := make(map[string]*ReviewerStats)
+	err := forge.ListPullRequests(org, repo, since, until, func(pr PullRequest) error {
+		reviewedByThisPR := make(map[string]bool)
+		for _, approval := range pr.Approvals {
+			stats, ok := byLogin[approval.Author]
+			if !ok {
+				stats = &ReviewerStats{Login: approval.Author}
+				byLogin[approval.Author] = stats
+			}
+			stats.Approvals++
+			if !reviewedByThisPR[approval.Author] {
+				stats.PRsReviewed++
+				reviewedByThisPR[approval.Author] = true
+			}
+			if stats.FirstReviewAt.IsZero() || approval.SubmittedAt.Before(stats.FirstReviewAt) {
+				stats.FirstReviewAt = approval.SubmittedAt
+			}
+			if approval.SubmittedAt.After(stats.LastReviewAt) {
+				stats.LastReviewAt = approval.SubmittedAt
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logins := make([]string, 0, len(byLogin))
+	for login := range byLogin {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+
+	stats := make([]ReviewerStats, len(logins))
+	for i, login := range logins {
+		stats[i] = *byLogin[login]
+	}
+	return stats, nil
+}